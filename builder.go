@@ -0,0 +1,179 @@
+// Copyright 2014-2015 Liu Dong <ddliuhb@gmail.com>.
+// Licensed under the MIT license.
+
+package dhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RequestBuilder offers a typed, chainable alternative to
+// WithOption/WithOptions for configuring a single request. It is just a
+// thin, discoverable layer on top of the existing option map, so code built
+// around WithOption keeps working unchanged.
+type RequestBuilder struct {
+	client  *Client
+	options map[int]interface{}
+	headers map[string]string
+	query   url.Values
+	body    io.Reader
+	err     error
+}
+
+// Request begins a typed, chainable configuration of a single request.
+func (client *Client) Request() *RequestBuilder {
+	return &RequestBuilder{
+		client:  client,
+		options: make(map[int]interface{}),
+		headers: make(map[string]string),
+	}
+}
+
+// Timeout sets the total request timeout (OPT_TIMEOUT_MS).
+func (builder *RequestBuilder) Timeout(timeout time.Duration) *RequestBuilder {
+	builder.options[OPT_TIMEOUT_MS] = int(timeout / time.Millisecond)
+	return builder
+}
+
+// ConnectTimeout sets the connect timeout (OPT_CONNECTTIMEOUT_MS).
+func (builder *RequestBuilder) ConnectTimeout(timeout time.Duration) *RequestBuilder {
+	builder.options[OPT_CONNECTTIMEOUT_MS] = int(timeout / time.Millisecond)
+	return builder
+}
+
+// Proxy sets the proxy address (OPT_PROXY), e.g. "user:pass@host:port".
+func (builder *RequestBuilder) Proxy(addr string) *RequestBuilder {
+	builder.options[OPT_PROXY] = addr
+	return builder
+}
+
+// ProxyType sets the proxy type (OPT_PROXYTYPE), e.g. PROXY_SOCKS5.
+func (builder *RequestBuilder) ProxyType(proxyType int) *RequestBuilder {
+	builder.options[OPT_PROXYTYPE] = proxyType
+	return builder
+}
+
+// UserAgent sets the User-Agent header (OPT_USERAGENT).
+func (builder *RequestBuilder) UserAgent(userAgent string) *RequestBuilder {
+	builder.options[OPT_USERAGENT] = userAgent
+	return builder
+}
+
+// FollowRedirects enables following redirects up to max times
+// (OPT_FOLLOWLOCATION, OPT_MAXREDIRS). A max of 0 disables redirects.
+func (builder *RequestBuilder) FollowRedirects(max int) *RequestBuilder {
+	builder.options[OPT_FOLLOWLOCATION] = max > 0
+	builder.options[OPT_MAXREDIRS] = max
+	return builder
+}
+
+// InsecureSkipVerify disables TLS certificate verification
+// (OPT_INSECURE_TLS).
+func (builder *RequestBuilder) InsecureSkipVerify(skip bool) *RequestBuilder {
+	builder.options[OPT_INSECURE_TLS] = skip
+	return builder
+}
+
+// RootCAs sets the CA pool used to verify the server certificate
+// (OPT_TLS_ROOT_CAS).
+func (builder *RequestBuilder) RootCAs(pool *x509.CertPool) *RequestBuilder {
+	builder.options[OPT_TLS_ROOT_CAS] = pool
+	return builder
+}
+
+// ClientCert sets the client certificate presented during the TLS handshake
+// (OPT_TLS_CLIENT_CERT).
+func (builder *RequestBuilder) ClientCert(cert tls.Certificate) *RequestBuilder {
+	builder.options[OPT_TLS_CLIENT_CERT] = cert
+	return builder
+}
+
+// ServerName overrides the hostname used to verify the server certificate
+// (OPT_TLS_SERVER_NAME).
+func (builder *RequestBuilder) ServerName(name string) *RequestBuilder {
+	builder.options[OPT_TLS_SERVER_NAME] = name
+	return builder
+}
+
+// Retries sets the number of retry attempts after a failed request
+// (OPT_RETRIES).
+func (builder *RequestBuilder) Retries(retries int) *RequestBuilder {
+	builder.options[OPT_RETRIES] = retries
+	return builder
+}
+
+// RetryBackoff sets the base delay used by the full-jitter exponential
+// backoff between retries (OPT_RETRY_BACKOFF).
+func (builder *RequestBuilder) RetryBackoff(backoff time.Duration) *RequestBuilder {
+	builder.options[OPT_RETRY_BACKOFF] = backoff
+	return builder
+}
+
+// RetryNonIdempotent allows retrying non-idempotent methods such as POST
+// and PATCH (OPT_RETRY_NON_IDEMPOTENT).
+func (builder *RequestBuilder) RetryNonIdempotent(allow bool) *RequestBuilder {
+	builder.options[OPT_RETRY_NON_IDEMPOTENT] = allow
+	return builder
+}
+
+// MaxBodySize caps the response body to at most maxBytes, protecting
+// against a hostile or misbehaving server exhausting client memory
+// (OPT_MAX_BODY_SIZE).
+func (builder *RequestBuilder) MaxBodySize(maxBytes int64) *RequestBuilder {
+	builder.options[OPT_MAX_BODY_SIZE] = maxBytes
+	return builder
+}
+
+// BasicAuth sets the Authorization header for HTTP basic authentication.
+func (builder *RequestBuilder) BasicAuth(user string, pass string) *RequestBuilder {
+	req := &http.Request{Header: make(http.Header)}
+	req.SetBasicAuth(user, pass)
+	builder.headers["Authorization"] = req.Header.Get("Authorization")
+	return builder
+}
+
+// JSON marshals v as the request body and sets the Content-Type header to
+// application/json.
+func (builder *RequestBuilder) JSON(v interface{}) *RequestBuilder {
+	data, err := json.Marshal(v)
+	if err != nil {
+		builder.err = err
+		return builder
+	}
+
+	builder.body = bytes.NewReader(data)
+	builder.headers["Content-Type"] = "application/json"
+
+	return builder
+}
+
+// Query adds query string parameters to the request URL.
+func (builder *RequestBuilder) Query(params url.Values) *RequestBuilder {
+	builder.query = params
+	return builder
+}
+
+// Do sends the request built so far, honoring ctx for cancellation.
+func (builder *RequestBuilder) Do(
+	ctx context.Context, method string, uri string,
+) (*Response, error) {
+	if builder.err != nil {
+		return nil, builder.err
+	}
+
+	if len(builder.query) > 0 {
+		uri = addQuery(uri, builder.query)
+	}
+
+	client := builder.client.WithOptions(builder.options).WithHeaders(builder.headers)
+
+	return client.do(ctx, method, uri, nil, builder.body)
+}
@@ -0,0 +1,37 @@
+package dhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestBuilderJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected Content-Type: %s", r.Header.Get("Content-Type"))
+		}
+
+		if r.URL.Query().Get("q") != "abc" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+
+	res, err := client.Request().
+		JSON(map[string]string{"hello": "world"}).
+		Query(map[string][]string{"q": {"abc"}}).
+		Do(context.Background(), "POST", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status: %d", res.StatusCode)
+	}
+}
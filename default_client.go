@@ -1,6 +1,7 @@
 package dhttp
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/url"
@@ -55,6 +56,15 @@ func Do(
 	return client.Do(method, url, headers, body)
 }
 
+// Like Do, but binds the request to ctx so it can be cancelled or given a
+// deadline by the caller.
+func DoCtx(
+	ctx context.Context, method string, url string,
+	headers map[string]string, body io.Reader,
+) (*Response, error) {
+	return client.DoCtx(ctx, method, url, headers, body)
+}
+
 // The GET request
 func Get(
 	url string, params url.Values,
@@ -62,6 +72,14 @@ func Get(
 	return client.Get(url, params)
 }
 
+// Like Get, but binds the request to ctx so it can be cancelled or given a
+// deadline by the caller.
+func GetCtx(
+	ctx context.Context, url string, params url.Values,
+) (*Response, error) {
+	return client.GetCtx(ctx, url, params)
+}
+
 // The POST request
 //
 // With multipart set to true, the request will be encoded as
@@ -75,6 +93,14 @@ func Post(
 	return client.Post(url, params)
 }
 
+// Like Post, but binds the request to ctx so it can be cancelled or given a
+// deadline by the caller.
+func PostCtx(
+	ctx context.Context, url string, params url.Values,
+) (*Response, error) {
+	return client.PostCtx(ctx, url, params)
+}
+
 // Post with the request encoded as "multipart/form-data".
 func PostMultipart(
 	url string, params url.Values,
@@ -82,6 +108,14 @@ func PostMultipart(
 	return client.PostMultipart(url, params)
 }
 
+// Like PostMultipart, but binds the request to ctx so it can be cancelled
+// or given a deadline by the caller.
+func PostMultipartCtx(
+	ctx context.Context, url string, params url.Values,
+) (*Response, error) {
+	return client.PostMultipartCtx(ctx, url, params)
+}
+
 // Get cookies of the client jar.
 func Cookies(uri string) []*http.Cookie {
 	return client.Cookies(uri)
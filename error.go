@@ -0,0 +1,23 @@
+// Copyright 2014-2015 Liu Dong <ddliuhb@gmail.com>.
+// Licensed under the MIT license.
+
+package dhttp
+
+import (
+	"fmt"
+)
+
+const (
+	ERR_REDIRECT_POLICY = iota
+)
+
+// Error represents an error produced internally by dhttp (as opposed to
+// errors returned directly from net/http).
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("dhttp: %s", err.Message)
+}
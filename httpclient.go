@@ -9,10 +9,10 @@ import (
 	"bytes"
 	"strings"
 
+	"context"
 	"time"
 
 	"io"
-	"io/ioutil"
 	"sync"
 
 	"net"
@@ -20,7 +20,8 @@ import (
 	"net/http/cookiejar"
 	"net/url"
 
-	"compress/gzip"
+	"crypto/tls"
+	"crypto/x509"
 
 	"mime/multipart"
 )
@@ -49,6 +50,18 @@ const (
 
 	OPT_REDIRECT_POLICY
 	OPT_PROXY_FUNC
+
+	OPT_INSECURE_TLS
+	OPT_TLS_ROOT_CAS
+	OPT_TLS_CLIENT_CERT
+	OPT_TLS_SERVER_NAME
+
+	OPT_RETRIES
+	OPT_RETRY_BACKOFF
+	OPT_RETRY_ON
+	OPT_RETRY_NON_IDEMPOTENT
+
+	OPT_MAX_BODY_SIZE
 )
 
 // Default options for any clients.
@@ -71,6 +84,10 @@ var transportOptions = []int{
 	OPT_INTERFACE,
 	OPT_PROXY,
 	OPT_PROXY_FUNC,
+	OPT_INSECURE_TLS,
+	OPT_TLS_ROOT_CAS,
+	OPT_TLS_CLIENT_CERT,
+	OPT_TLS_SERVER_NAME,
 }
 
 // These options affect cookie jar, jar may not be reused if you change any of
@@ -79,39 +96,6 @@ var jarOptions = []int{
 	OPT_COOKIEJAR,
 }
 
-// Thin wrapper of http.Response(can also be used as http.Response).
-type Response struct {
-	*http.Response
-}
-
-// Read response body into a byte slice.
-func (respoonse *Response) ReadAll() ([]byte, error) {
-	var reader io.ReadCloser
-	var err error
-	switch respoonse.Header.Get("Content-Encoding") {
-	case "gzip":
-		reader, err = gzip.NewReader(respoonse.Body)
-		if err != nil {
-			return nil, err
-		}
-	default:
-		reader = respoonse.Body
-	}
-
-	defer reader.Close()
-	return ioutil.ReadAll(reader)
-}
-
-// Read response body into string.
-func (respoonse *Response) ToString() (string, error) {
-	bytes, err := respoonse.ReadAll()
-	if err != nil {
-		return "", err
-	}
-
-	return string(bytes), nil
-}
-
 // Prepare a request.
 func prepareRequest(method string, url string, headers map[string]string,
 	body io.Reader, options map[int]interface{}) (*http.Request, error) {
@@ -182,21 +166,17 @@ func prepareTransport(options map[int]interface{}) (http.RoundTripper, error) {
 		connectTimeoutMS = timeoutMS
 	}
 
-	transport.Dial = func(network, addr string) (net.Conn, error) {
-		var conn net.Conn
-		var err error
-		if connectTimeoutMS > 0 {
-			conn, err = net.DialTimeout(
-				network, addr, time.Duration(connectTimeoutMS)*time.Millisecond,
-			)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			conn, err = net.Dial(network, addr)
-			if err != nil {
-				return nil, err
-			}
+	netDialer := &net.Dialer{}
+	if connectTimeoutMS > 0 {
+		netDialer.Timeout = time.Duration(connectTimeoutMS) * time.Millisecond
+	}
+
+	// dialDirect connects straight to addr, honoring ctx so an in-flight
+	// connect is aborted if the caller cancels the request.
+	dialDirect := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := netDialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
 		}
 
 		if timeoutMS > 0 {
@@ -208,60 +188,143 @@ func prepareTransport(options map[int]interface{}) (http.RoundTripper, error) {
 		return conn, nil
 	}
 
+	// boundDialer adapts dialDirect to the ctx-less dial signature expected
+	// by dialProxy, for connecting to the proxy itself.
+	boundDialer := func(ctx context.Context) func(network, addr string) (net.Conn, error) {
+		return func(network, addr string) (net.Conn, error) {
+			return dialDirect(ctx, network, addr)
+		}
+	}
+
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialDirect(ctx, network, addr)
+	}
+
 	// proxy
 	if proxyFunc_, ok := options[OPT_PROXY_FUNC]; ok {
-		if proxyFunc, ok := proxyFunc_.(func(*http.Request) (int, string, error)); ok {
-			transport.Proxy = func(req *http.Request) (*url.URL, error) {
-				proxyType, uri, err := proxyFunc(req)
-				if err != nil {
-					return nil, err
-				}
+		proxyFunc, ok := proxyFunc_.(func(*http.Request) (int, string, error))
+		if !ok {
+			return nil, fmt.Errorf("OPT_PROXY_FUNC is not a desired function")
+		}
 
-				if proxyType != PROXY_HTTP {
-					return nil, fmt.Errorf("only PROXY_HTTP is currently supported")
-				}
+		// Proxy yields a proxy URL only for PROXY_HTTP; for the SOCKS proxy
+		// types it returns nil and the tunneling happens in DialContext below
+		// instead.
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			proxyType, uri, err := proxyFunc(req)
+			if err != nil {
+				return nil, err
+			}
 
-				uri = "http://" + uri
+			if proxyType != PROXY_HTTP {
+				return nil, nil
+			}
 
-				parsedURL, err := url.Parse(uri)
+			return url.Parse("http://" + uri)
+		}
 
-				if err != nil {
-					return nil, err
-				}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			proxyType, uri, err := proxyFunc(&http.Request{URL: &url.URL{Host: addr}})
+			if err != nil {
+				return nil, err
+			}
 
-				return parsedURL, nil
+			if proxyType == PROXY_HTTP {
+				return dialDirect(ctx, network, addr)
 			}
-		} else {
-			return nil, fmt.Errorf("OPT_PROXY_FUNC is not a desired function")
+
+			return dialProxy(proxyType, uri, network, addr, boundDialer(ctx))
 		}
 	} else {
 		var proxytype int
 		if proxytype_, ok := options[OPT_PROXYTYPE]; ok {
-			if proxytype, ok = proxytype_.(int); !ok || proxytype != PROXY_HTTP {
-				return nil, fmt.Errorf(
-					"OPT_PROXYTYPE must be int, " +
-						"and only PROXY_HTTP is currently supported",
-				)
+			if proxytype, ok = proxytype_.(int); !ok {
+				return nil, fmt.Errorf("OPT_PROXYTYPE must be int")
 			}
 		}
 
-		var proxy string
+		var proxyAddr string
 		if proxy_, ok := options[OPT_PROXY]; ok {
-			if proxy, ok = proxy_.(string); !ok {
+			if proxyAddr, ok = proxy_.(string); !ok {
 				return nil, fmt.Errorf("OPT_PROXY must be string")
 			}
-			proxy = "http://" + proxy
-			proxyUrl, err := url.Parse(proxy)
-			if err != nil {
-				return nil, err
+		}
+
+		if proxyAddr != "" {
+			switch proxytype {
+			case PROXY_HTTP:
+				proxyURL, err := url.Parse("http://" + proxyAddr)
+				if err != nil {
+					return nil, err
+				}
+				transport.Proxy = http.ProxyURL(proxyURL)
+			case PROXY_SOCKS4, PROXY_SOCKS4A, PROXY_SOCKS5:
+				transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+					return dialProxy(proxytype, proxyAddr, network, addr, boundDialer(ctx))
+				}
+			default:
+				return nil, fmt.Errorf("unsupported OPT_PROXYTYPE: %d", proxytype)
 			}
-			transport.Proxy = http.ProxyURL(proxyUrl)
 		}
 	}
 
+	// tls
+	tlsConfig, err := prepareTLSConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
 	return transport, nil
 }
 
+// Prepare a tls.Config from the TLS-related options, or nil if none were
+// set (leaving http.Transport to use its defaults).
+func prepareTLSConfig(options map[int]interface{}) (*tls.Config, error) {
+	var tlsConfig *tls.Config
+
+	ensureConfig := func() *tls.Config {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		return tlsConfig
+	}
+
+	if insecure_, ok := options[OPT_INSECURE_TLS]; ok {
+		insecure, ok := insecure_.(bool)
+		if !ok {
+			return nil, fmt.Errorf("OPT_INSECURE_TLS must be bool")
+		}
+		ensureConfig().InsecureSkipVerify = insecure
+	}
+
+	if rootCAs_, ok := options[OPT_TLS_ROOT_CAS]; ok {
+		rootCAs, ok := rootCAs_.(*x509.CertPool)
+		if !ok {
+			return nil, fmt.Errorf("OPT_TLS_ROOT_CAS must be *x509.CertPool")
+		}
+		ensureConfig().RootCAs = rootCAs
+	}
+
+	if clientCert_, ok := options[OPT_TLS_CLIENT_CERT]; ok {
+		clientCert, ok := clientCert_.(tls.Certificate)
+		if !ok {
+			return nil, fmt.Errorf("OPT_TLS_CLIENT_CERT must be tls.Certificate")
+		}
+		ensureConfig().Certificates = append(ensureConfig().Certificates, clientCert)
+	}
+
+	if serverName_, ok := options[OPT_TLS_SERVER_NAME]; ok {
+		serverName, ok := serverName_.(string)
+		if !ok {
+			return nil, fmt.Errorf("OPT_TLS_SERVER_NAME must be string")
+		}
+		ensureConfig().ServerName = serverName
+	}
+
+	return tlsConfig, nil
+}
+
 // Prepare a redirect policy.
 func prepareRedirect(
 	options map[int]interface{},
@@ -385,6 +448,10 @@ type Client struct {
 
 	// Make requests of one client concurrent safe.
 	lock *sync.Mutex
+
+	// Middleware registered via Use, wrapped around the transport of every
+	// request made by this client.
+	middlewares []Middleware
 }
 
 // Set default options and headers.
@@ -498,6 +565,23 @@ func (client *Client) WithCookie(cookies ...*http.Cookie) *Client {
 // Usually we just need the Get and Post method.
 func (client *Client) Do(
 	method string, url string, headers map[string]string, body io.Reader,
+) (*Response, error) {
+	return client.DoCtx(context.Background(), method, url, headers, body)
+}
+
+// Like Do, but binds the request to ctx so it can be cancelled or given a
+// deadline by the caller.
+func (client *Client) DoCtx(
+	ctx context.Context, method string, url string,
+	headers map[string]string, body io.Reader,
+) (*Response, error) {
+	return client.do(ctx, method, url, headers, body)
+}
+
+// do is the shared implementation behind DoCtx and the request builder's Do.
+func (client *Client) do(
+	ctx context.Context, method string, url string,
+	headers map[string]string, body io.Reader,
 ) (*Response, error) {
 	var (
 		transport http.RoundTripper
@@ -554,36 +638,74 @@ func (client *Client) Do(
 	}
 
 	httpClient := &http.Client{
-		Transport:     transport,
+		Transport:     client.chain(transport),
 		CheckRedirect: redirect,
 		Jar:           jar,
 	}
 
-	req, err := prepareRequest(method, url, headers, body, options)
+	retry, err := prepareRetry(options, method)
 	if err != nil {
 		return nil, err
 	}
 
-	if jar != nil {
-		jar.SetCookies(req.URL, cookies)
-	} else {
-		for _, cookie := range cookies {
-			req.AddCookie(cookie)
+	nextBody, err := retryableBody(body, retry.retries > 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var maxBodySize int64
+	if maxBodySize_, ok := options[OPT_MAX_BODY_SIZE]; ok {
+		switch size := maxBodySize_.(type) {
+		case int64:
+			maxBodySize = size
+		case int:
+			maxBodySize = int64(size)
+		default:
+			return nil, fmt.Errorf("OPT_MAX_BODY_SIZE must be int64 or int")
 		}
 	}
 
-	res, err := httpClient.Do(req)
+	attempt := func() (*Response, error) {
+		req, err := prepareRequest(method, url, headers, nextBody(), options)
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
 
-	return &Response{res}, err
+		if jar != nil {
+			jar.SetCookies(req.URL, cookies)
+		} else {
+			for _, cookie := range cookies {
+				req.AddCookie(cookie)
+			}
+		}
+
+		res, err := httpClient.Do(req)
+		if err == nil {
+			capBody(res, maxBodySize)
+		}
+
+		return &Response{res}, err
+	}
+
+	return doWithRetry(ctx, retry, attempt)
 }
 
 // The GET request
 func (client *Client) Get(
 	url string, params url.Values,
+) (*Response, error) {
+	return client.GetCtx(context.Background(), url, params)
+}
+
+// Like Get, but binds the request to ctx so it can be cancelled or given a
+// deadline by the caller.
+func (client *Client) GetCtx(
+	ctx context.Context, url string, params url.Values,
 ) (*Response, error) {
 	url = addQuery(url, params)
 
-	return client.Do("GET", url, nil, nil)
+	return client.DoCtx(ctx, "GET", url, nil, nil)
 }
 
 // The POST request
@@ -595,10 +717,18 @@ func (client *Client) Get(
 // (similar to CURL but different).
 func (client *Client) Post(
 	url string, params url.Values,
+) (*Response, error) {
+	return client.PostCtx(context.Background(), url, params)
+}
+
+// Like Post, but binds the request to ctx so it can be cancelled or given a
+// deadline by the caller.
+func (client *Client) PostCtx(
+	ctx context.Context, url string, params url.Values,
 ) (*Response, error) {
 	// Post with files should be sent as multipart.
 	if checkParamFile(params) {
-		return client.PostMultipart(url, params)
+		return client.PostMultipartCtx(ctx, url, params)
 	}
 
 	headers := map[string]string{
@@ -607,14 +737,21 @@ func (client *Client) Post(
 
 	body := strings.NewReader(params.Encode())
 
-	return client.Do("POST", url, headers, body)
+	return client.DoCtx(ctx, "POST", url, headers, body)
 }
 
 // Post with the request encoded as "multipart/form-data".
 func (client *Client) PostMultipart(
 	url string, params url.Values,
 ) (*Response, error) {
+	return client.PostMultipartCtx(context.Background(), url, params)
+}
 
+// Like PostMultipart, but binds the request to ctx so it can be cancelled
+// or given a deadline by the caller.
+func (client *Client) PostMultipartCtx(
+	ctx context.Context, url string, params url.Values,
+) (*Response, error) {
 	var (
 		body   = &bytes.Buffer{}
 		writer = multipart.NewWriter(body)
@@ -642,7 +779,7 @@ func (client *Client) PostMultipart(
 		return nil, err
 	}
 
-	return client.Do("POST", url, headers, body)
+	return client.DoCtx(ctx, "POST", url, headers, body)
 }
 
 // Get cookies of the client jar.
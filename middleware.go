@@ -0,0 +1,37 @@
+// Copyright 2014-2015 Liu Dong <ddliuhb@gmail.com>.
+// Licensed under the MIT license.
+
+package dhttp
+
+import (
+	"net/http"
+)
+
+// Middleware wraps an http.RoundTripper, letting callers add cross-cutting
+// behavior (logging, metrics, tracing, caching, auth-refresh, circuit
+// breaking, ...) around a request without touching Client.Do.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// Use registers middleware to wrap the client's transport. Middlewares run
+// outermost-first in registration order: the first one registered sees the
+// request first and the response last.
+func (client *Client) Use(middlewares ...Middleware) *Client {
+	client.middlewares = append(client.middlewares, middlewares...)
+	return client
+}
+
+// chain composes transport with the registered middlewares.
+func (client *Client) chain(transport http.RoundTripper) http.RoundTripper {
+	for i := len(client.middlewares) - 1; i >= 0; i-- {
+		transport = client.middlewares[i](transport)
+	}
+
+	return transport
+}
+
+// roundTripperFunc adapts a function to the http.RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
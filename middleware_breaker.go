@@ -0,0 +1,48 @@
+// Copyright 2014-2015 Liu Dong <ddliuhb@gmail.com>.
+// Licensed under the MIT license.
+
+package dhttp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/sony/gobreaker"
+)
+
+// errBreakerServerError is only used internally to make gobreaker count a
+// 5xx response as a failure; it is never returned to the caller.
+var errBreakerServerError = errors.New("dhttp: server error")
+
+// CircuitBreakerMiddleware short-circuits requests through breaker once it
+// trips (by default after repeated 5xx responses or transport errors),
+// returning the breaker's error (e.g. gobreaker.ErrOpenState) instead of
+// dialing out. Construct breaker with gobreaker.NewCircuitBreaker.
+func CircuitBreakerMiddleware(breaker *gobreaker.CircuitBreaker) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			var roundTripErr error
+
+			result, err := breaker.Execute(func() (interface{}, error) {
+				res, resErr := next.RoundTrip(req)
+				roundTripErr = resErr
+
+				if resErr == nil && res.StatusCode >= 500 {
+					return res, errBreakerServerError
+				}
+
+				return res, resErr
+			})
+
+			if err == gobreaker.ErrOpenState || err == gobreaker.ErrTooManyRequests {
+				return nil, err
+			}
+
+			if result == nil {
+				return nil, roundTripErr
+			}
+
+			return result.(*http.Response), roundTripErr
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2014-2015 Liu Dong <ddliuhb@gmail.com>.
+// Licensed under the MIT license.
+
+package dhttp
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// RedactHeader reports whether a header's value should be redacted before
+// being logged.
+type RedactHeader func(header string) bool
+
+// DefaultRedactHeader redacts the Authorization and Cookie headers.
+func DefaultRedactHeader(header string) bool {
+	switch http.CanonicalHeaderKey(header) {
+	case "Authorization", "Cookie":
+		return true
+	default:
+		return false
+	}
+}
+
+// LoggingMiddleware logs each request's method, URL, status and duration to
+// logger, redacting any header for which redact returns true. A nil redact
+// defaults to DefaultRedactHeader.
+func LoggingMiddleware(logger *log.Logger, redact RedactHeader) Middleware {
+	if redact == nil {
+		redact = DefaultRedactHeader
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			started := time.Now()
+
+			res, err := next.RoundTrip(req)
+
+			status := "error"
+			if res != nil {
+				status = res.Status
+			}
+
+			logger.Printf(
+				"%s %s -> %s (%s) headers=%s",
+				req.Method, req.URL, status,
+				time.Since(started), redactHeaders(req.Header, redact),
+			)
+
+			return res, err
+		})
+	}
+}
+
+// redactHeaders returns a copy of headers with the value of every header
+// for which redact returns true replaced by a placeholder.
+func redactHeaders(headers http.Header, redact RedactHeader) http.Header {
+	redacted := make(http.Header, len(headers))
+	for name, values := range headers {
+		if redact(name) {
+			redacted[name] = []string{"[redacted]"}
+			continue
+		}
+		redacted[name] = values
+	}
+
+	return redacted
+}
@@ -0,0 +1,79 @@
+// Copyright 2014-2015 Liu Dong <ddliuhb@gmail.com>.
+// Licensed under the MIT license.
+
+package dhttp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the Prometheus collectors used by MetricsMiddleware.
+// Register it with a prometheus.Registerer (it implements
+// prometheus.Collector) and pass it to MetricsMiddleware.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+// NewMetrics creates the collectors backing MetricsMiddleware, with metric
+// names prefixed by prefix (e.g. "dhttp" produces "dhttp_requests_total").
+func NewMetrics(prefix string) *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: prefix + "_requests_total",
+			Help: "Total number of HTTP requests made by the client.",
+		}, []string{"method", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: prefix + "_request_duration_seconds",
+			Help: "HTTP request latency in seconds.",
+		}, []string{"method", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: prefix + "_requests_in_flight",
+			Help: "Number of HTTP requests currently in flight.",
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (metrics *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	metrics.requests.Describe(ch)
+	metrics.latency.Describe(ch)
+	metrics.inFlight.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (metrics *Metrics) Collect(ch chan<- prometheus.Metric) {
+	metrics.requests.Collect(ch)
+	metrics.latency.Collect(ch)
+	metrics.inFlight.Collect(ch)
+}
+
+// MetricsMiddleware records request counts, latency and in-flight requests
+// to metrics.
+func MetricsMiddleware(metrics *Metrics) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			metrics.inFlight.Inc()
+			defer metrics.inFlight.Dec()
+
+			started := time.Now()
+			res, err := next.RoundTrip(req)
+			duration := time.Since(started).Seconds()
+
+			status := "error"
+			if res != nil {
+				status = strconv.Itoa(res.StatusCode)
+			}
+
+			metrics.requests.WithLabelValues(req.Method, status).Inc()
+			metrics.latency.WithLabelValues(req.Method, status).Observe(duration)
+
+			return res, err
+		})
+	}
+}
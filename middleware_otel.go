@@ -0,0 +1,48 @@
+// Copyright 2014-2015 Liu Dong <ddliuhb@gmail.com>.
+// Licensed under the MIT license.
+
+package dhttp
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OTelMiddleware starts a client span (named after the request method)
+// around each request using the tracer named instrumentationName, and
+// injects the configured propagator's trace context into the outgoing
+// headers so the receiving service can continue the trace.
+func OTelMiddleware(instrumentationName string) Middleware {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, span := tracer.Start(
+				req.Context(), req.Method,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("http.method", req.Method),
+					attribute.String("http.url", req.URL.String()),
+				),
+			)
+			defer span.End()
+
+			req = req.WithContext(ctx)
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			res, err := next.RoundTrip(req)
+			if err != nil {
+				span.RecordError(err)
+				return res, err
+			}
+
+			span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+
+			return res, err
+		})
+	}
+}
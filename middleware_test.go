@@ -0,0 +1,70 @@
+package dhttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+func TestUseWrapsTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var seenHeader string
+
+	client := NewClient()
+	client.Use(func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			seenHeader = req.Header.Get("X-From-Middleware")
+			return next.RoundTrip(req)
+		})
+	})
+	client.WithHeader("X-From-Middleware", "yes")
+
+	_, err := client.Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if seenHeader != "yes" {
+		t.Errorf("middleware did not see the request, got header %q", seenHeader)
+	}
+}
+
+func TestCircuitBreakerMiddlewareTrips(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	breaker := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= 2
+		},
+		Timeout: time.Hour,
+	})
+
+	client := NewClient()
+	client.Use(CircuitBreakerMiddleware(breaker))
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(server.URL, nil)
+		if err != nil {
+			t.Fatalf("attempt %d: unexpected transport error: %s", i, err)
+		}
+		if res.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("attempt %d: unexpected status: %d", i, res.StatusCode)
+		}
+	}
+
+	_, err := client.Get(server.URL, nil)
+	if !errors.Is(err, gobreaker.ErrOpenState) {
+		t.Errorf("expected breaker to be open, got err=%v", err)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2014-2015 Liu Dong <ddliuhb@gmail.com>.
+// Licensed under the MIT license.
+
+package dhttp
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Thin wrapper of http.Response(can also be used as http.Response).
+type Response struct {
+	*http.Response
+}
+
+// decodedReader wraps the response body with a decompressing reader
+// according to its Content-Encoding, or returns it unchanged.
+func (respoonse *Response) decodedReader() (io.ReadCloser, error) {
+	switch respoonse.Header.Get("Content-Encoding") {
+	case "gzip":
+		return gzip.NewReader(respoonse.Body)
+	case "deflate":
+		return zlib.NewReader(respoonse.Body)
+	case "br":
+		return ioutil.NopCloser(brotli.NewReader(respoonse.Body)), nil
+	default:
+		return respoonse.Body, nil
+	}
+}
+
+// Read response body into a byte slice.
+func (respoonse *Response) ReadAll() ([]byte, error) {
+	reader, err := respoonse.decodedReader()
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// Read response body into string.
+func (respoonse *Response) ToString() (string, error) {
+	bytes, err := respoonse.ReadAll()
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
+// Bytes reads at most maxBytes of the (decompressed) response body. A
+// maxBytes of 0 or less means unlimited.
+func (respoonse *Response) Bytes(maxBytes int64) ([]byte, error) {
+	reader, err := respoonse.decodedReader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if maxBytes <= 0 {
+		return ioutil.ReadAll(reader)
+	}
+
+	return ioutil.ReadAll(io.LimitReader(reader, maxBytes))
+}
+
+// JSON decodes the response body as JSON into v.
+func (respoonse *Response) JSON(v interface{}) error {
+	reader, err := respoonse.decodedReader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return json.NewDecoder(reader).Decode(v)
+}
+
+// XML decodes the response body as XML into v.
+func (respoonse *Response) XML(v interface{}) error {
+	reader, err := respoonse.decodedReader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return xml.NewDecoder(reader).Decode(v)
+}
+
+// Cancel aborts an in-flight response by closing its body, causing any
+// pending or future Read to return an error. Prefer cancelling the
+// context.Context passed to DoCtx/GetCtx/etc where possible; Cancel is for
+// callers that already hold a Response and want to stop reading it.
+func (respoonse *Response) Cancel() error {
+	if respoonse.Response == nil || respoonse.Body == nil {
+		return nil
+	}
+
+	return respoonse.Body.Close()
+}
+
+// Cap the response body to at most maxBodySize bytes, limiting how much a
+// hostile server can send. Streaming consumers of res.Body get the same cap.
+// The cap counts the body bytes as read from res.Body; ReadAll/JSON/XML
+// decode a deflate, br or gzip Content-Encoding after the cap, so their
+// decoded output can be larger than maxBodySize.
+func capBody(res *http.Response, maxBodySize int64) {
+	if maxBodySize <= 0 || res == nil || res.Body == nil {
+		return
+	}
+
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.LimitReader(res.Body, maxBodySize),
+		Closer: res.Body,
+	}
+}
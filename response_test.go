@@ -0,0 +1,84 @@
+package dhttp
+
+import (
+	"compress/zlib"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"hello":"world"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+
+	res, err := client.Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		Hello string `json:"hello"`
+	}
+	if err := res.JSON(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Hello != "world" {
+		t.Errorf("unexpected body: %+v", body)
+	}
+}
+
+func TestResponseDeflate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "deflate")
+		writer := zlib.NewWriter(w)
+		writer.Write([]byte("hello, deflate"))
+		writer.Close()
+	}))
+	defer server.Close()
+
+	client := NewClient()
+
+	res, err := client.Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	str, err := res.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if str != "hello, deflate" {
+		t.Errorf("unexpected body: %q", str)
+	}
+}
+
+func TestResponseMaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.WithOption(OPT_MAX_BODY_SIZE, int64(4))
+
+	res, err := client.Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := res.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "0123" {
+		t.Errorf("unexpected body: %q", string(data))
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2014-2015 Liu Dong <ddliuhb@gmail.com>.
+// Licensed under the MIT license.
+
+package dhttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryBackoff = 100 * time.Millisecond
+	maxRetryBackoff     = 30 * time.Second
+)
+
+// retry holds the per-request retry configuration resolved from the option
+// map.
+type retry struct {
+	retries         int
+	backoff         time.Duration
+	retryOn         func(*Response, error) bool
+	idempotent      bool
+	nonIdempotentOK bool
+}
+
+// Prepare the retry configuration for a request (OPT_RETRIES,
+// OPT_RETRY_BACKOFF, OPT_RETRY_ON, OPT_RETRY_NON_IDEMPOTENT).
+func prepareRetry(options map[int]interface{}, method string) (retry, error) {
+	r := retry{
+		backoff:    defaultRetryBackoff,
+		retryOn:    defaultRetryOn,
+		idempotent: isIdempotent(method),
+	}
+
+	if retries_, ok := options[OPT_RETRIES]; ok {
+		if r.retries, ok = retries_.(int); !ok {
+			return r, fmt.Errorf("OPT_RETRIES must be int")
+		}
+	}
+
+	if backoff_, ok := options[OPT_RETRY_BACKOFF]; ok {
+		if r.backoff, ok = backoff_.(time.Duration); !ok {
+			return r, fmt.Errorf("OPT_RETRY_BACKOFF must be time.Duration")
+		}
+	}
+
+	if retryOn_, ok := options[OPT_RETRY_ON]; ok {
+		if r.retryOn, ok = retryOn_.(func(*Response, error) bool); !ok {
+			return r, fmt.Errorf("OPT_RETRY_ON is not a desired function")
+		}
+	}
+
+	if nonIdempotent_, ok := options[OPT_RETRY_NON_IDEMPOTENT]; ok {
+		if r.nonIdempotentOK, ok = nonIdempotent_.(bool); !ok {
+			return r, fmt.Errorf("OPT_RETRY_NON_IDEMPOTENT must be bool")
+		}
+	}
+
+	return r, nil
+}
+
+// isIdempotent reports whether method is safe to retry by default.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut,
+		http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// defaultRetryOn is used when OPT_RETRY_ON isn't set: retry on temporary
+// network errors, connection resets, and 5xx/429 responses.
+func defaultRetryOn(res *Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Temporary() {
+			return true
+		}
+
+		return errors.Is(err, syscall.ECONNRESET)
+	}
+
+	if res == nil || res.Response == nil {
+		return false
+	}
+
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// doWithRetry runs attempt, retrying according to r using full-jitter
+// exponential backoff and honoring Retry-After response headers.
+func doWithRetry(
+	ctx context.Context, r retry, attempt func() (*Response, error),
+) (*Response, error) {
+	if !r.idempotent && !r.nonIdempotentOK {
+		return attempt()
+	}
+
+	for try := 0; ; try++ {
+		res, err := attempt()
+
+		if try >= r.retries || !r.retryOn(res, err) {
+			return res, err
+		}
+
+		wait := retryAfter(res)
+		if wait == 0 {
+			wait = fullJitterBackoff(r.backoff, try)
+		}
+
+		if res != nil && res.Response != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return res, ctx.Err()
+		}
+	}
+}
+
+// fullJitterBackoff computes sleep = rand(0, min(cap, base * 2^attempt)).
+func fullJitterBackoff(base time.Duration, attempt int) time.Duration {
+	capped := time.Duration(float64(base) * math.Pow(2, float64(attempt)))
+	if capped < 0 || capped > maxRetryBackoff {
+		capped = maxRetryBackoff
+	}
+
+	return time.Duration(rand.Int63n(int64(capped) + 1))
+}
+
+// retryAfter returns the delay requested by a Retry-After header, in either
+// the seconds or HTTP-date form, or 0 if absent.
+func retryAfter(res *Response) time.Duration {
+	if res == nil || res.Response == nil {
+		return 0
+	}
+
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if when, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(when); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// retryableBody returns a function producing a fresh, replayable reader for
+// body on each call. When retries are disabled the original reader is
+// returned as-is (it is only ever read once). When enabled, a
+// io.ReadSeeker is rewound, and any other reader is buffered in memory so it
+// can be replayed on retry.
+func retryableBody(body io.Reader, retriesEnabled bool) (func() io.Reader, error) {
+	if body == nil {
+		return func() io.Reader { return nil }, nil
+	}
+
+	if !retriesEnabled {
+		return func() io.Reader { return body }, nil
+	}
+
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		return func() io.Reader {
+			seeker.Seek(0, io.SeekStart)
+			return seeker
+		}, nil
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() io.Reader {
+		return bytes.NewReader(data)
+	}, nil
+}
@@ -0,0 +1,71 @@
+package dhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDoRetriesOnServerError(t *testing.T) {
+	var attempts int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.WithOption(OPT_RETRIES, 5)
+	client.WithOption(OPT_RETRY_BACKOFF, time.Millisecond)
+
+	res, err := client.Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status: %d", res.StatusCode)
+	}
+
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestDoDoesNotRetryNonIdempotentByDefault(t *testing.T) {
+	var attempts int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.WithOption(OPT_RETRIES, 5)
+	client.WithOption(OPT_RETRY_BACKOFF, time.Millisecond)
+
+	_, err := client.Post(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt for non-idempotent method, got %d", attempts)
+	}
+}
+
+func TestFullJitterBackoffIsBounded(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		wait := fullJitterBackoff(10*time.Millisecond, attempt)
+		if wait < 0 || wait > maxRetryBackoff {
+			t.Errorf("attempt %d: wait %s out of bounds", attempt, wait)
+		}
+	}
+}
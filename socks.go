@@ -0,0 +1,152 @@
+// Copyright 2014-2015 Liu Dong <ddliuhb@gmail.com>.
+// Licensed under the MIT license.
+
+package dhttp
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/proxy"
+)
+
+// Split a proxy address of the form "user:pass@host:port" (or plain
+// "host:port") into its auth and host:port parts.
+func splitProxyAuth(addr string) (user string, pass string, hostport string) {
+	at := strings.LastIndex(addr, "@")
+	if at < 0 {
+		return "", "", addr
+	}
+
+	auth := addr[:at]
+	hostport = addr[at+1:]
+
+	if colon := strings.Index(auth, ":"); colon >= 0 {
+		user = auth[:colon]
+		pass = auth[colon+1:]
+	} else {
+		user = auth
+	}
+
+	return user, pass, hostport
+}
+
+// dialProxy connects to addr through the given proxy, dialing the proxy
+// itself with dial.
+func dialProxy(
+	proxyType int, proxyAddr string,
+	network string, addr string,
+	dial func(network, addr string) (net.Conn, error),
+) (net.Conn, error) {
+	user, pass, hostport := splitProxyAuth(proxyAddr)
+
+	switch proxyType {
+	case PROXY_SOCKS5:
+		var auth *proxy.Auth
+		if user != "" {
+			auth = &proxy.Auth{User: user, Password: pass}
+		}
+
+		dialer, err := proxy.SOCKS5(network, hostport, auth, proxyDialer(dial))
+		if err != nil {
+			return nil, err
+		}
+
+		return dialer.Dial(network, addr)
+	case PROXY_SOCKS4, PROXY_SOCKS4A:
+		conn, err := dial(network, hostport)
+		if err != nil {
+			return nil, err
+		}
+
+		err = socks4Connect(conn, addr, user, proxyType == PROXY_SOCKS4A)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return conn, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy type: %d", proxyType)
+	}
+}
+
+// proxyDialer adapts a dial function to the proxy.Dialer interface expected
+// by golang.org/x/net/proxy.
+type proxyDialer func(network, addr string) (net.Conn, error)
+
+func (dial proxyDialer) Dial(network, addr string) (net.Conn, error) {
+	return dial(network, addr)
+}
+
+const (
+	socks4Version    = 0x04
+	socks4CmdConnect = 0x01
+	socks4Granted    = 0x5a
+)
+
+// socks4Connect performs a SOCKS4/SOCKS4A CONNECT handshake for addr over
+// conn, which must already be connected to the SOCKS server.
+//
+// For plain SOCKS4 the host in addr must be resolvable to an IPv4 address
+// (the client, not the proxy, resolves it). SOCKS4A instead sends the
+// hostname to the proxy using the 0.0.0.x sentinel IP.
+func socks4Connect(conn net.Conn, addr string, userid string, socks4a bool) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("invalid port in %q: %s", addr, err)
+	}
+
+	var ip net.IP
+	if socks4a {
+		ip = net.IPv4(0, 0, 0, 1).To4()
+	} else {
+		ip = net.ParseIP(host).To4()
+		if ip == nil {
+			resolved, err := net.ResolveIPAddr("ip4", host)
+			if err != nil {
+				return fmt.Errorf("socks4: resolve %q: %s", host, err)
+			}
+			ip = resolved.IP.To4()
+		}
+	}
+
+	request := make([]byte, 0, 9+len(userid)+1+len(host)+1)
+	request = append(request, socks4Version, socks4CmdConnect)
+	request = append(request, byte(port>>8), byte(port))
+	request = append(request, ip...)
+	request = append(request, []byte(userid)...)
+	request = append(request, 0)
+
+	if socks4a {
+		request = append(request, []byte(host)...)
+		request = append(request, 0)
+	}
+
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 8)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+
+	if reply[0] != 0 {
+		return fmt.Errorf("socks4: malformed reply, expected VN=0, got %d", reply[0])
+	}
+
+	if reply[1] != socks4Granted {
+		return fmt.Errorf("socks4: request rejected or failed, CD=%d", reply[1])
+	}
+
+	return nil
+}
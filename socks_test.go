@@ -0,0 +1,55 @@
+package dhttp
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestSplitProxyAuth(t *testing.T) {
+	user, pass, hostport := splitProxyAuth("alice:secret@proxy.example.com:1080")
+	if user != "alice" || pass != "secret" || hostport != "proxy.example.com:1080" {
+		t.Errorf("got (%q, %q, %q)", user, pass, hostport)
+	}
+
+	user, pass, hostport = splitProxyAuth("proxy.example.com:1080")
+	if user != "" || pass != "" || hostport != "proxy.example.com:1080" {
+		t.Errorf("got (%q, %q, %q)", user, pass, hostport)
+	}
+}
+
+func TestSocks4AConnectEmitsFourByteDSTIP(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- socks4Connect(client, "example.com:1080", "user", true)
+	}()
+
+	request := make([]byte, 8+len("user")+1+len("example.com")+1)
+	if _, err := io.ReadFull(server, request); err != nil {
+		t.Fatal(err)
+	}
+
+	dstip := request[4:8]
+	if !bytes.Equal(dstip, []byte{0, 0, 0, 1}) {
+		t.Errorf("expected DSTIP 0.0.0.1, got %v", dstip)
+	}
+
+	rest := request[8:]
+	want := append([]byte("user\x00"), []byte("example.com\x00")...)
+	if !bytes.Equal(rest, want) {
+		t.Errorf("expected USERID+hostname %q, got %q", want, rest)
+	}
+
+	if _, err := server.Write([]byte{0, 0x5a, 0, 0, 0, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
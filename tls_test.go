@@ -0,0 +1,32 @@
+package dhttp
+
+import (
+	"testing"
+)
+
+func TestPrepareTLSConfig(t *testing.T) {
+	tlsConfig, err := prepareTLSConfig(map[int]interface{}{
+		OPT_INSECURE_TLS:    true,
+		OPT_TLS_SERVER_NAME: "example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tlsConfig.InsecureSkipVerify {
+		t.Error("expected InsecureSkipVerify to be true")
+	}
+
+	if tlsConfig.ServerName != "example.com" {
+		t.Errorf("unexpected ServerName: %s", tlsConfig.ServerName)
+	}
+
+	tlsConfig, err = prepareTLSConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tlsConfig != nil {
+		t.Error("expected nil tls.Config when no TLS options are set")
+	}
+}